@@ -0,0 +1,227 @@
+package wireguard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var interfaceKeys = map[string]bool{
+	"PrivateKey": true, "Address": true, "ListenPort": true,
+	"DNS": true, "MTU": true, "PostUp": true, "PostDown": true,
+}
+
+var peerKeys = map[string]bool{
+	"PublicKey": true, "PresharedKey": true, "AllowedIPs": true,
+	"Endpoint": true, "PersistentKeepalive": true,
+}
+
+// ImportConfig 解析一份标准的 wg-quick INI 格式配置（[Interface] + 若干 [Peer]），
+// 生成一个新的 WireGuardServer，并把每个 [Peer] 作为它的客户端
+func (s *Service) ImportConfig(r io.Reader, name string) (*WireGuardServer, error) {
+	server := &WireGuardServer{Name: name}
+	var current *WireGuardClient
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			switch section {
+			case "Interface":
+				current = nil
+			case "Peer":
+				server.Clients = append(server.Clients, WireGuardClient{})
+				current = &server.Clients[len(server.Clients)-1]
+			default:
+				return nil, fmt.Errorf("未知的配置节 [%s]", section)
+			}
+			continue
+		}
+
+		key, value, err := splitKV(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch section {
+		case "Interface":
+			if !interfaceKeys[key] {
+				return nil, fmt.Errorf("[Interface] 中出现未知字段 %q", key)
+			}
+			if err := applyInterfaceKey(server, key, value); err != nil {
+				return nil, err
+			}
+		case "Peer":
+			if current == nil {
+				return nil, fmt.Errorf("字段 %q 出现在 [Peer] 节之外", key)
+			}
+			if !peerKeys[key] {
+				return nil, fmt.Errorf("[Peer] 中出现未知字段 %q", key)
+			}
+			if err := applyPeerKey(current, key, value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("字段 %q 出现在任何配置节之前", key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if server.PrivateKey == "" {
+		return nil, fmt.Errorf("配置缺少 [Interface] PrivateKey")
+	}
+	pub, err := publicKeyFromPrivate(server.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("推导公钥失败: %v", err)
+	}
+	server.PublicKey = pub
+
+	server.ID = uuid.New().String()
+	server.Tag = sanitizeTag(name)
+	if server.MTU == 0 {
+		server.MTU = 1420
+	}
+	server.CreatedAt = time.Now()
+	server.UpdatedAt = time.Now()
+	for i := range server.Clients {
+		server.Clients[i].ID = uuid.New().String()
+		server.Clients[i].Name = fmt.Sprintf("%s-peer-%d", name, i+1)
+		server.Clients[i].Enabled = true
+		server.Clients[i].CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	s.config.Servers = append(s.config.Servers, *server)
+	err = s.saveConfig()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// ExportConfig 把一个服务器导出成标准的 wg-quick 配置，与 ImportConfig 对称
+func (s *Service) ExportConfig(serverID string) ([]byte, error) {
+	server, err := s.GetServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(renderWgQuickConfig(server)), nil
+}
+
+func applyInterfaceKey(server *WireGuardServer, key, value string) error {
+	switch key {
+	case "PrivateKey":
+		server.PrivateKey = value
+	case "Address":
+		if server.Address == "" {
+			server.Address = value
+		} else {
+			server.Address = server.Address + "," + value
+		}
+	case "ListenPort":
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("ListenPort 不是合法的数字: %q", value)
+		}
+		server.ListenPort = port
+	case "DNS":
+		server.DNS = value
+	case "MTU":
+		mtu, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("MTU 不是合法的数字: %q", value)
+		}
+		server.MTU = mtu
+	case "PostUp":
+		server.PostUp = value
+	case "PostDown":
+		server.PostDown = value
+	}
+	return nil
+}
+
+func applyPeerKey(client *WireGuardClient, key, value string) error {
+	switch key {
+	case "PublicKey":
+		client.PublicKey = value
+	case "PresharedKey":
+		client.PresharedKey = value
+	case "AllowedIPs":
+		parts := strings.Split(value, ",")
+		for i, p := range parts {
+			parts[i] = strings.TrimSpace(p)
+		}
+		client.AllowedIPs = strings.Join(parts, ",")
+	case "Endpoint":
+		client.Endpoint = value
+	case "PersistentKeepalive":
+		ka, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("PersistentKeepalive 不是合法的数字: %q", value)
+		}
+		client.PersistentKeepalive = ka
+	}
+	return nil
+}
+
+// stripComment 去掉整行注释（# 或 ; 开头）。分号只在这种整行场景下算注释，
+// 不支持行内写法——像 `PostUp = iptables ... ; iptables ...` 这种值里间的分号
+// 是命令分隔符，一旦把它当成行内注释就会把第二条命令连同换行一起吞掉。
+// # 则额外允许前面有空白的行内写法，这是 wg-quick 配置里常见的用法。
+func stripComment(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return ""
+	}
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			line = line[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// splitKV 解析一行 "Key = value"，并去掉 value 两侧的引号
+func splitKV(line string) (string, string, error) {
+	idx := strings.Index(line, "=")
+	if idx < 0 {
+		return "", "", fmt.Errorf("无法解析的配置行: %q", line)
+	}
+	key := strings.TrimSpace(line[:idx])
+	value := strings.TrimSpace(line[idx+1:])
+	value = strings.Trim(value, `"`)
+	return key, value, nil
+}
+
+// sanitizeTag 把服务器名字变成一个适合当接口名的短字符串
+func sanitizeTag(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, name)
+	if name == "" {
+		return "wg0"
+	}
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package wireguard
+
+import "testing"
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "whole-line hash comment",
+			line: "# this is a comment",
+			want: "",
+		},
+		{
+			name: "whole-line semicolon comment",
+			line: "; this is a comment",
+			want: "",
+		},
+		{
+			name: "indented whole-line comment",
+			line: "   # indented comment",
+			want: "",
+		},
+		{
+			name: "inline hash comment",
+			line: "MTU = 1420 # keep it small",
+			want: "MTU = 1420",
+		},
+		{
+			name: "semicolon inside value is preserved",
+			line: "PostUp = iptables -A FORWARD -j ACCEPT ; iptables -t nat -A POSTROUTING -j MASQUERADE",
+			want: "PostUp = iptables -A FORWARD -j ACCEPT ; iptables -t nat -A POSTROUTING -j MASQUERADE",
+		},
+		{
+			name: "plain line untouched",
+			line: "PrivateKey = abc123",
+			want: "PrivateKey = abc123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripComment(tt.line); got != tt.want {
+				t.Fatalf("stripComment(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
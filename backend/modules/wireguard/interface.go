@@ -0,0 +1,131 @@
+package wireguard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// InterfaceStatus 接口运行状态
+type InterfaceStatus struct {
+	Up bool
+}
+
+// ifaceBackend 是数据面的抽象：kernel 后端调用 wg-quick，
+// userspace 后端在进程内跑 wireguard-go，二者实现同一套
+// StartInterface/StopInterface/Status 语义。
+type ifaceBackend interface {
+	Start(server *WireGuardServer) error
+	Stop(tag string) error
+	Status(tag string) (*InterfaceStatus, error)
+	// Stats 返回该接口下所有 peer 的状态，以 peer 的 base64 公钥为 key
+	Stats(tag string) (map[string]PeerStats, error)
+	// UpdatePeers 只替换 peer 列表，不动私钥/监听端口，用于不中断现有连接的热更新
+	UpdatePeers(server *WireGuardServer) error
+}
+
+// backend 返回当前配置选中的后端，默认使用内核 wg-quick
+func (s *Service) backend() ifaceBackend {
+	if s.config.Backend == "userspace" {
+		return s.userspaceBackend()
+	}
+	return &kernelBackend{dataDir: s.dataDir}
+}
+
+// StartInterface 启动服务器对应的 WireGuard 接口
+func (s *Service) StartInterface(server *WireGuardServer) error {
+	return s.backend().Start(server)
+}
+
+// StopInterface 停止指定接口
+func (s *Service) StopInterface(tag string) error {
+	return s.backend().Stop(tag)
+}
+
+// Status 查询接口是否处于运行状态
+func (s *Service) Status(tag string) (*InterfaceStatus, error) {
+	return s.backend().Status(tag)
+}
+
+// kernelBackend 通过 wg-quick 操作内核 WireGuard 模块，仅支持 Linux
+type kernelBackend struct {
+	dataDir string
+}
+
+func (k *kernelBackend) confPath(tag string) string {
+	return filepath.Join(k.dataDir, tag+".conf")
+}
+
+func (k *kernelBackend) Start(server *WireGuardServer) error {
+	conf := renderWgQuickConfig(server)
+	if err := os.WriteFile(k.confPath(server.Tag), []byte(conf), 0600); err != nil {
+		return fmt.Errorf("写入接口配置失败: %v", err)
+	}
+	cmd := exec.Command("wg-quick", "up", k.confPath(server.Tag))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("wg-quick up 失败: %v: %s", err, out)
+	}
+	return nil
+}
+
+func (k *kernelBackend) Stop(tag string) error {
+	cmd := exec.Command("wg-quick", "down", k.confPath(tag))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("wg-quick down 失败: %v: %s", err, out)
+	}
+	return nil
+}
+
+func (k *kernelBackend) Status(tag string) (*InterfaceStatus, error) {
+	err := exec.Command("ip", "link", "show", tag).Run()
+	return &InterfaceStatus{Up: err == nil}, nil
+}
+
+// Stats 连接内核接口的 UAPI unix socket，发送 get=1 并解析响应，不再 shell 出 `wg show`
+func (k *kernelBackend) Stats(tag string) (map[string]PeerStats, error) {
+	conn, err := dialUAPI(tag)
+	if err != nil {
+		return nil, fmt.Errorf("连接 UAPI socket 失败: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("get=1\n\n")); err != nil {
+		return nil, fmt.Errorf("写入 UAPI 请求失败: %v", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("读取 UAPI 响应失败: %v", err)
+	}
+
+	return parseUAPIDump(string(buf[:n])), nil
+}
+
+// UpdatePeers 通过 UAPI 热更新 peer 列表，不重启接口、不影响其它已建立的连接
+func (k *kernelBackend) UpdatePeers(server *WireGuardServer) error {
+	conn, err := dialUAPI(server.Tag)
+	if err != nil {
+		return fmt.Errorf("连接 UAPI socket 失败: %v", err)
+	}
+	defer conn.Close()
+
+	// UAPI 的写操作必须以 set=1 开头声明这是一条配置指令，否则内核会
+	// 把整条消息当成未知请求丢弃，peer 列表也就不会被替换。
+	req := "set=1\n" + renderUAPIPeersOnly(server) + "\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return fmt.Errorf("写入 UAPI 请求失败: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return fmt.Errorf("读取 UAPI 响应失败: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), "errno=0") {
+		return fmt.Errorf("UAPI 拒绝了配置更新: %s", strings.TrimSpace(string(buf[:n])))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package wireguard
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrIPPoolExhausted 表示某个网段里已经没有可用地址分配给新客户端了
+var ErrIPPoolExhausted = errors.New("地址池已耗尽")
+
+// allocateClientIP 按服务器的网段给新客户端分配地址。server.Address 可以是
+// 逗号分隔的多个 CIDR（例如 "10.0.0.1/24,fd00::1/64"），这种情况下会为每个
+// 地址族各分配一个地址，结果同样以逗号分隔返回，可直接写入 client.AllowedIPs。
+func (s *Service) allocateClientIP(server *WireGuardServer) (string, error) {
+	used := map[netip.Addr]bool{}
+	for _, c := range server.Clients {
+		for _, ipStr := range strings.Split(c.AllowedIPs, ",") {
+			if addr, err := parseHostAddr(ipStr); err == nil {
+				used[addr] = true
+			}
+		}
+	}
+
+	var allocated []string
+	for _, prefixStr := range strings.Split(server.Address, ",") {
+		prefixStr = strings.TrimSpace(prefixStr)
+		if prefixStr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(prefixStr)
+		if err != nil {
+			return "", fmt.Errorf("服务器地址 %q 不是合法的 CIDR: %v", prefixStr, err)
+		}
+
+		addr, err := allocateFromPrefix(prefix, used)
+		if err != nil {
+			return "", err
+		}
+		used[addr] = true
+		allocated = append(allocated, hostCIDR(addr, prefix))
+	}
+
+	if len(allocated) == 0 {
+		return "", fmt.Errorf("服务器未配置地址")
+	}
+	return strings.Join(allocated, ","), nil
+}
+
+// allocateFromPrefix 在一个网段里找到第一个未被占用的主机地址，跳过网络地址、
+// 网段的最后一个地址（IPv4 的广播地址；IPv6 的最后一个地址同样不分配）以及
+// 服务器自己的地址（prefix.Addr()，即 server.Address
+// 里写的那个具体 IP，不假定它一定是网段的第一个地址）
+func allocateFromPrefix(prefix netip.Prefix, used map[netip.Addr]bool) (netip.Addr, error) {
+	network := prefix.Masked().Addr()
+	broadcast := lastAddr(prefix)
+	serverAddr := prefix.Addr()
+
+	used[network] = true
+	used[serverAddr] = true
+	if prefix.Addr().Is4() {
+		used[broadcast] = true
+	}
+
+	for addr := network.Next(); prefix.Contains(addr); addr = addr.Next() {
+		if addr == broadcast {
+			break
+		}
+		if !used[addr] {
+			return addr, nil
+		}
+	}
+
+	return netip.Addr{}, ErrIPPoolExhausted
+}
+
+// lastAddr 返回网段里的最后一个地址（IPv4 的广播地址，IPv6 没有广播地址这个概念
+// 但同样作为网段边界使用）
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	base := prefix.Masked().Addr()
+	bytes := base.AsSlice()
+	bits := prefix.Bits()
+	for i := range bytes {
+		remaining := bits - i*8
+		switch {
+		case remaining <= 0:
+			bytes[i] = 0xff
+		case remaining < 8:
+			bytes[i] |= 0xff >> remaining
+		}
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	if base.Is4() {
+		addr = addr.Unmap()
+	}
+	return addr
+}
+
+// hostCIDR 把一个主机地址格式化成 /32（IPv4）或 /128（IPv6）的 CIDR 字符串
+func hostCIDR(addr netip.Addr, prefix netip.Prefix) string {
+	if prefix.Addr().Is4() {
+		return fmt.Sprintf("%s/32", addr)
+	}
+	return fmt.Sprintf("%s/128", addr)
+}
+
+// parseHostAddr 从一个 "addr/bits" 或裸地址字符串里取出地址部分
+func parseHostAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, fmt.Errorf("空地址")
+	}
+	if idx := strings.IndexByte(s, '/'); idx >= 0 {
+		s = s[:idx]
+	}
+	return netip.ParseAddr(s)
+}
@@ -0,0 +1,105 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAllocateClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		used      []string // 已有客户端的 AllowedIPs
+		wantCIDRs int      // 期望分配出的地址段数量（dual-stack 时 > 1）
+		wantErr   bool
+	}{
+		{
+			name:      "/24 first client",
+			address:   "10.0.0.1/24",
+			wantCIDRs: 1,
+		},
+		{
+			name:      "/16 skips used addresses",
+			address:   "10.10.0.1/16",
+			used:      []string{"10.10.0.2/32", "10.10.0.3/32"},
+			wantCIDRs: 1,
+		},
+		{
+			name:      "/23 spans two octets",
+			address:   "10.0.0.1/23",
+			used:      genSequential("10.0.0.", 2, 255),
+			wantCIDRs: 1,
+		},
+		{
+			name:      "IPv6 /64",
+			address:   "fd00::1/64",
+			wantCIDRs: 1,
+		},
+		{
+			name:      "dual-stack allocates v4 and v6",
+			address:   "10.0.0.1/24,fd00::1/64",
+			wantCIDRs: 2,
+		},
+		{
+			// .0 网络地址、.1 服务器自己、.2 已被占用、.3 广播地址（IPv4），
+			// 四个地址全部用完，这个 /30 里真的再挤不出一个客户端地址了
+			name:      "/30 pool exhausted",
+			address:   "10.0.0.1/30",
+			used:      []string{"10.0.0.2/32"},
+			wantErr:   true,
+		},
+		{
+			name:      "server not at first host is still skipped",
+			address:   "10.0.0.10/24",
+			wantCIDRs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &WireGuardServer{Address: tt.address}
+			for _, ip := range tt.used {
+				server.Clients = append(server.Clients, WireGuardClient{AllowedIPs: ip})
+			}
+
+			s := &Service{}
+			got, err := s.allocateClientIP(server)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			parts := strings.Split(got, ",")
+			if len(parts) != tt.wantCIDRs {
+				t.Fatalf("got %d CIDRs (%v), want %d", len(parts), parts, tt.wantCIDRs)
+			}
+
+			for _, used := range tt.used {
+				if got == used {
+					t.Fatalf("allocated address %q collides with existing client", got)
+				}
+			}
+
+			serverHost := strings.SplitN(strings.Split(tt.address, ",")[0], "/", 2)[0]
+			for _, part := range parts {
+				if strings.SplitN(part, "/", 2)[0] == serverHost {
+					t.Fatalf("allocated address %q collides with server's own address %q", got, serverHost)
+				}
+			}
+		})
+	}
+}
+
+func genSequential(prefix string, from, to int) []string {
+	var out []string
+	for i := from; i <= to; i++ {
+		out = append(out, fmt.Sprintf("%s%d/32", prefix, i))
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package wireguard
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// KeyPair 一对 WireGuard Curve25519 密钥
+type KeyPair struct {
+	PrivateKey string
+	PublicKey  string
+}
+
+// GenerateKeyPair 生成一对 WireGuard 密钥
+func GenerateKeyPair() (*KeyPair, error) {
+	var priv [32]byte
+	if _, err := rand.Read(priv[:]); err != nil {
+		return nil, err
+	}
+	// 按照 WireGuard 的规范 clamp 私钥
+	priv[0] &= 248
+	priv[31] &= 127
+	priv[31] |= 64
+
+	pub, err := curve25519.X25519(priv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		PrivateKey: base64.StdEncoding.EncodeToString(priv[:]),
+		PublicKey:  base64.StdEncoding.EncodeToString(pub),
+	}, nil
+}
+
+// publicKeyFromPrivate 从 base64 编码的私钥推导出对应的公钥，
+// 用于导入只带 PrivateKey 的 wg-quick 配置时补全 PublicKey
+func publicKeyFromPrivate(privB64 string) (string, error) {
+	priv, err := base64.StdEncoding.DecodeString(privB64)
+	if err != nil {
+		return "", fmt.Errorf("私钥不是合法的 base64: %v", err)
+	}
+	if len(priv) != 32 {
+		return "", fmt.Errorf("私钥长度应为 32 字节，实际为 %d", len(priv))
+	}
+
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), nil
+}
+
+// GeneratePresharedKey 生成预共享密钥
+func GeneratePresharedKey() (string, error) {
+	var psk [32]byte
+	if _, err := rand.Read(psk[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(psk[:]), nil
+}
@@ -0,0 +1,88 @@
+package wireguard
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SitePeer 是一个站点到站点的对端：不是单台设备，而是另一侧网关，
+// 通过 RemoteSubnets 宣告它背后的网段，供本端装进路由/AllowedIPs
+type SitePeer struct {
+	ID                  string         `json:"id"`
+	Name                string         `json:"name"`
+	PublicKey           string         `json:"publicKey"`
+	PresharedKey        string         `json:"presharedKey,omitempty"`
+	Endpoint            string         `json:"endpoint"`
+	RemoteSubnets       []netip.Prefix `json:"remoteSubnets"`
+	PersistentKeepalive int            `json:"persistentKeepalive,omitempty"`
+}
+
+// AddSitePeer 给服务器加一个站点对端，使其成为 hub-and-spoke 拓扑里的一个 spoke。
+// hub 端把每个 site 的 RemoteSubnets 装进该 peer 的 AllowedIPs，并在 PostUp 为空时
+// 打开 IP 转发，site 之间的流量经 hub 转发；各 site 自己那一侧指向其它 site 网段的
+// 路由不在这里生成。
+func (s *Service) AddSitePeer(serverID string, peer *SitePeer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if peer.PublicKey == "" {
+		return fmt.Errorf("站点对端必须提供公钥")
+	}
+	if len(peer.RemoteSubnets) == 0 {
+		return fmt.Errorf("站点对端必须至少声明一个远程网段")
+	}
+
+	for i := range s.config.Servers {
+		if s.config.Servers[i].ID == serverID {
+			server := &s.config.Servers[i]
+			peer.ID = uuid.New().String()
+			server.SitePeers = append(server.SitePeers, *peer)
+			server.UpdatedAt = time.Now()
+
+			if err := s.saveConfig(); err != nil {
+				return err
+			}
+			if server.Enabled {
+				return s.reloadInterface(server)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("服务器不存在")
+}
+
+// DeleteSitePeer 从服务器移除一个站点对端
+func (s *Service) DeleteSitePeer(serverID, peerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.config.Servers {
+		if s.config.Servers[i].ID == serverID {
+			server := &s.config.Servers[i]
+			for j := range server.SitePeers {
+				if server.SitePeers[j].ID == peerID {
+					server.SitePeers = append(server.SitePeers[:j], server.SitePeers[j+1:]...)
+					server.UpdatedAt = time.Now()
+					if err := s.saveConfig(); err != nil {
+						return err
+					}
+					if server.Enabled {
+						return s.reloadInterface(server)
+					}
+					return nil
+				}
+			}
+			return fmt.Errorf("站点对端不存在")
+		}
+	}
+	return fmt.Errorf("服务器不存在")
+}
+
+// reloadInterface 用最新的配置重启接口，让新增/删除的 peer 立即生效
+func (s *Service) reloadInterface(server *WireGuardServer) error {
+	_ = s.backend().Stop(server.Tag)
+	return s.backend().Start(server)
+}
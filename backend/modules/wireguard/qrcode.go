@@ -0,0 +1,62 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// RenderClientConfig 生成一份可以直接导入官方 WireGuard App 的客户端配置：
+// [Interface] 用客户端自己的私钥/地址/DNS，[Peer] 指向本服务器
+func (s *Service) RenderClientConfig(serverID, clientID string) (string, error) {
+	server, client, err := s.findServerAndClient(serverID, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Interface]\n")
+	fmt.Fprintf(&b, "PrivateKey = %s\n", client.PrivateKey)
+	fmt.Fprintf(&b, "Address = %s\n", client.AllowedIPs)
+	if client.DNS != "" {
+		fmt.Fprintf(&b, "DNS = %s\n", client.DNS)
+	}
+
+	b.WriteString("\n[Peer]\n")
+	fmt.Fprintf(&b, "PublicKey = %s\n", server.PublicKey)
+	if client.PresharedKey != "" {
+		fmt.Fprintf(&b, "PresharedKey = %s\n", client.PresharedKey)
+	}
+	fmt.Fprintf(&b, "AllowedIPs = 0.0.0.0/0, ::/0\n")
+	if server.Endpoint != "" {
+		fmt.Fprintf(&b, "Endpoint = %s\n", server.Endpoint)
+	}
+	fmt.Fprintf(&b, "PersistentKeepalive = 25\n")
+
+	return b.String(), nil
+}
+
+// RenderClientQRCode 把客户端配置渲染成一张 PNG 二维码，供 WireGuard iOS/Android
+// App 的「扫码添加」功能直接使用
+func (s *Service) RenderClientQRCode(serverID, clientID string) ([]byte, error) {
+	conf, err := s.RenderClientConfig(serverID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.Encode(conf, qrcode.Medium, 256)
+}
+
+// findServerAndClient 同时取出服务器和其下某个客户端的只读快照
+func (s *Service) findServerAndClient(serverID, clientID string) (*WireGuardServer, *WireGuardClient, error) {
+	server, err := s.GetServer(serverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range server.Clients {
+		if server.Clients[i].ID == clientID {
+			return server, &server.Clients[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("客户端不存在")
+}
@@ -0,0 +1,91 @@
+package wireguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderWgQuickConfig 把服务器及其客户端渲染成 wg-quick 风格的 [Interface]/[Peer] 配置
+func renderWgQuickConfig(server *WireGuardServer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Interface]\n")
+	fmt.Fprintf(&b, "PrivateKey = %s\n", server.PrivateKey)
+	fmt.Fprintf(&b, "Address = %s\n", server.Address)
+	fmt.Fprintf(&b, "ListenPort = %d\n", server.ListenPort)
+	if server.MTU > 0 {
+		fmt.Fprintf(&b, "MTU = %d\n", server.MTU)
+	}
+	if server.DNS != "" {
+		fmt.Fprintf(&b, "DNS = %s\n", server.DNS)
+	}
+	if server.PostUp != "" {
+		fmt.Fprintf(&b, "PostUp = %s\n", server.PostUp)
+	}
+	if server.PostDown != "" {
+		fmt.Fprintf(&b, "PostDown = %s\n", server.PostDown)
+	}
+	if len(server.SitePeers) > 0 && server.PostUp == "" {
+		// 站点对端需要在 peer 之间转发流量，打开转发才能组成 hub-and-spoke
+		b.WriteString("PostUp = sysctl -w net.ipv4.ip_forward=1; sysctl -w net.ipv6.conf.all.forwarding=1\n")
+	}
+
+	// 宽限期内的旧公钥要写在对应客户端 *之前*：wg 对同一个 AllowedIPs 只认
+	// 最后配置的那个 peer，必须让新密钥的客户端条目后写入才会拿到路由，
+	// 否则宽限期内新设备发得出、收不到。
+	for _, rot := range server.PendingRotations {
+		if rot.ClientID == "" {
+			// 服务器自身换钥不适用 peer 重叠，不应该出现在这里
+			continue
+		}
+		b.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&b, "PublicKey = %s\n", rot.OldPublicKey)
+		if rot.OldPresharedKey != "" {
+			fmt.Fprintf(&b, "PresharedKey = %s\n", rot.OldPresharedKey)
+		}
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", rot.AllowedIPs)
+	}
+
+	for _, c := range server.Clients {
+		if !c.Enabled {
+			continue
+		}
+		b.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&b, "PublicKey = %s\n", c.PublicKey)
+		if c.PresharedKey != "" {
+			fmt.Fprintf(&b, "PresharedKey = %s\n", c.PresharedKey)
+		}
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", c.AllowedIPs)
+		if c.Endpoint != "" {
+			fmt.Fprintf(&b, "Endpoint = %s\n", c.Endpoint)
+		}
+		if c.PersistentKeepalive > 0 {
+			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", c.PersistentKeepalive)
+		}
+	}
+
+	for _, p := range server.SitePeers {
+		b.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&b, "PublicKey = %s\n", p.PublicKey)
+		if p.PresharedKey != "" {
+			fmt.Fprintf(&b, "PresharedKey = %s\n", p.PresharedKey)
+		}
+		if p.Endpoint != "" {
+			fmt.Fprintf(&b, "Endpoint = %s\n", p.Endpoint)
+		}
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", siteAllowedIPs(p))
+		if p.PersistentKeepalive > 0 {
+			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", p.PersistentKeepalive)
+		}
+	}
+
+	return b.String()
+}
+
+// siteAllowedIPs 把一个站点对端的远程网段拼成 wg 的 AllowedIPs 列表
+func siteAllowedIPs(p SitePeer) string {
+	subnets := make([]string, len(p.RemoteSubnets))
+	for i, prefix := range p.RemoteSubnets {
+		subnets[i] = prefix.String()
+	}
+	return strings.Join(subnets, ", ")
+}
@@ -0,0 +1,178 @@
+package wireguard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultRotationGrace 是 RotationGrace 未配置时使用的默认宽限期
+const defaultRotationGrace = 24 * time.Hour
+
+// rotationSweepInterval 是后台协程检查宽限期是否到期的间隔
+const rotationSweepInterval = time.Minute
+
+// rotationGrace 返回配置的宽限期，未配置时落回默认值
+func (s *Service) rotationGrace() time.Duration {
+	if s.config.RotationGrace > 0 {
+		return s.config.RotationGrace
+	}
+	return defaultRotationGrace
+}
+
+// RotateServerKey 给服务器生成一对新的密钥并立即生效。一台服务器的身份就是
+// 接口本身的密钥，不是某个 peer 条目，所以这里不适用宽限期重叠：旧公钥写在
+// 每个客户端的配置里，换钥之后那些配置本身就过期了，需要带外重新下发给客户端
+// （比如重新生成并分发 RenderClientConfig/二维码），而不是让旧公钥继续当 peer。
+func (s *Service) RotateServerKey(serverID string) error {
+	s.mu.Lock()
+	var server *WireGuardServer
+	for i := range s.config.Servers {
+		if s.config.Servers[i].ID == serverID {
+			server = &s.config.Servers[i]
+			break
+		}
+	}
+	if server == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("服务器不存在")
+	}
+
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("生成密钥失败: %v", err)
+	}
+
+	server.PrivateKey = keyPair.PrivateKey
+	server.PublicKey = keyPair.PublicKey
+	server.UpdatedAt = time.Now()
+
+	enabled := server.Enabled
+	serverCopy := *server
+	err = s.saveConfig()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		return s.reloadInterface(&serverCopy)
+	}
+	return nil
+}
+
+// RotateClientKey 给某个客户端生成新的密钥对，旧公钥作为次要 peer 保留
+// RotationGrace 时长后自动失效，中间这段时间新旧设备都能连上。
+func (s *Service) RotateClientKey(serverID, clientID string) error {
+	s.mu.Lock()
+	var server *WireGuardServer
+	for i := range s.config.Servers {
+		if s.config.Servers[i].ID == serverID {
+			server = &s.config.Servers[i]
+			break
+		}
+	}
+	if server == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("服务器不存在")
+	}
+
+	var client *WireGuardClient
+	for i := range server.Clients {
+		if server.Clients[i].ID == clientID {
+			client = &server.Clients[i]
+			break
+		}
+	}
+	if client == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("客户端不存在")
+	}
+
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("生成密钥失败: %v", err)
+	}
+	psk, err := GeneratePresharedKey()
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("生成预共享密钥失败: %v", err)
+	}
+
+	oldPublicKey := client.PublicKey
+	oldPresharedKey := client.PresharedKey
+	client.PrivateKey = keyPair.PrivateKey
+	client.PublicKey = keyPair.PublicKey
+	client.PresharedKey = psk
+
+	server.PendingRotations = append(server.PendingRotations, PendingRotation{
+		ID:              uuid.New().String(),
+		ClientID:        clientID,
+		OldPublicKey:    oldPublicKey,
+		OldPresharedKey: oldPresharedKey,
+		AllowedIPs:      client.AllowedIPs,
+		ExpiresAt:       time.Now().Add(s.rotationGrace()),
+	})
+	server.UpdatedAt = time.Now()
+
+	enabled := server.Enabled
+	serverCopy := *server
+	err = s.saveConfig()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		return s.backend().UpdatePeers(&serverCopy)
+	}
+	return nil
+}
+
+// startRotationSweeper 启动后台协程，周期性地清理过期的密钥轮换宽限期
+func (s *Service) startRotationSweeper() {
+	go func() {
+		ticker := time.NewTicker(rotationSweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.sweepExpiredRotations()
+		}
+	}()
+}
+
+func (s *Service) sweepExpiredRotations() {
+	s.mu.Lock()
+	var toReload []WireGuardServer
+	now := time.Now()
+	for i := range s.config.Servers {
+		server := &s.config.Servers[i]
+		kept := server.PendingRotations[:0]
+		changed := false
+		for _, rot := range server.PendingRotations {
+			if now.After(rot.ExpiresAt) {
+				changed = true
+				continue
+			}
+			kept = append(kept, rot)
+		}
+		server.PendingRotations = kept
+		if changed {
+			server.UpdatedAt = now
+			if server.Enabled {
+				toReload = append(toReload, *server)
+			}
+		}
+	}
+	err := s.saveConfig()
+	s.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+	for i := range toReload {
+		_ = s.backend().UpdatePeers(&toReload[i])
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +21,12 @@ type Service struct {
 	configPath string
 	config     WireGuardConfig
 	mu         sync.RWMutex
+
+	us   *userspaceBackendImpl
+	usMu sync.Mutex
+
+	statsCache map[string]map[string]PeerStats // serverID -> peer 公钥 -> 状态
+	statsMu    sync.RWMutex
 }
 
 // NewService 创建服务
@@ -31,6 +36,8 @@ func NewService(dataDir string) *Service {
 		configPath: filepath.Join(dataDir, "wireguard.json"),
 	}
 	s.loadConfig()
+	s.startStatsPoller()
+	s.startRotationSweeper()
 	return s
 }
 
@@ -65,29 +72,72 @@ func IsLinux() bool {
 	return runtime.GOOS == "linux"
 }
 
-// CheckInstalled 检查 WireGuard 是否安装
+// CheckInstalled 检查 WireGuard 是否可用
+// userspace 后端内嵌了 wireguard-go，不依赖 wg/wg-quick 外部工具
 func (s *Service) CheckInstalled() bool {
+	if s.config.Backend == "userspace" {
+		return true
+	}
 	_, err := exec.LookPath("wg")
 	return err == nil
 }
 
-// GetServers 获取所有服务器
+// GetServers 获取所有服务器，附带最近一次轮询到的 peer 在线状态
 func (s *Service) GetServers() []WireGuardServer {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.config.Servers
+	servers := make([]WireGuardServer, len(s.config.Servers))
+	for i := range s.config.Servers {
+		servers[i] = cloneServer(&s.config.Servers[i])
+	}
+	s.mu.RUnlock()
+
+	for i := range servers {
+		s.applyStats(&servers[i])
+	}
+	return servers
+}
+
+// cloneServer 深拷贝 Clients 切片，避免调用方通过返回值修改运行时字段（如
+// Stats/Online）时意外污染 s.config 里真正持久化的数据
+func cloneServer(server *WireGuardServer) WireGuardServer {
+	clone := *server
+	clone.Clients = append([]WireGuardClient(nil), server.Clients...)
+	return clone
 }
 
-// GetServer 获取服务器
+// GetServer 获取服务器，附带最近一次轮询到的 peer 在线状态
 func (s *Service) GetServer(id string) (*WireGuardServer, error) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	var found *WireGuardServer
 	for i := range s.config.Servers {
 		if s.config.Servers[i].ID == id {
-			return &s.config.Servers[i], nil
+			server := cloneServer(&s.config.Servers[i])
+			found = &server
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if found == nil {
+		return nil, fmt.Errorf("服务器不存在")
+	}
+	s.applyStats(found)
+	return found, nil
+}
+
+// applyStats 把缓存的 peer 状态合并进一份服务器快照的 Clients 里
+func (s *Service) applyStats(server *WireGuardServer) {
+	byPubKey := s.cachedStats(server.ID)
+	if len(byPubKey) == 0 {
+		return
+	}
+	for i := range server.Clients {
+		if st, ok := byPubKey[server.Clients[i].PublicKey]; ok {
+			stCopy := st
+			server.Clients[i].Stats = &stCopy
+			server.Clients[i].Online = isOnline(st.LastHandshake)
 		}
 	}
-	return nil, fmt.Errorf("服务器不存在")
 }
 
 // CreateServer 创建服务器
@@ -163,7 +213,11 @@ func (s *Service) AddClient(serverID string, client *WireGuardClient) error {
 
 			// 智能分配 IP（避免冲突）
 			if client.AllowedIPs == "" {
-				client.AllowedIPs = s.allocateClientIP(server)
+				allocated, err := s.allocateClientIP(server)
+				if err != nil {
+					return fmt.Errorf("分配 IP 失败: %v", err)
+				}
+				client.AllowedIPs = allocated
 			}
 
 			// 继承服务器 DNS
@@ -178,54 +232,6 @@ func (s *Service) AddClient(serverID string, client *WireGuardClient) error {
 	return fmt.Errorf("服务器不存在")
 }
 
-// allocateClientIP 智能分配客户端 IP（避免冲突）
-func (s *Service) allocateClientIP(server *WireGuardServer) string {
-	// 解析服务器地址，获取网段前缀
-	baseIP := strings.Split(server.Address, "/")[0]
-	parts := strings.Split(baseIP, ".")
-	if len(parts) != 4 {
-		return "10.0.0.2/32" // 兜底
-	}
-
-	prefix := fmt.Sprintf("%s.%s.%s", parts[0], parts[1], parts[2])
-
-	// 收集已使用的 IP
-	usedIPs := make(map[int]bool)
-	// 服务器自身 IP
-	if serverIP := strings.Split(baseIP, "."); len(serverIP) == 4 {
-		if num, err := parseInt(serverIP[3]); err == nil {
-			usedIPs[num] = true
-		}
-	}
-	// 已有客户端 IP
-	for _, c := range server.Clients {
-		ip := strings.Split(c.AllowedIPs, "/")[0]
-		ipParts := strings.Split(ip, ".")
-		if len(ipParts) == 4 {
-			if num, err := parseInt(ipParts[3]); err == nil {
-				usedIPs[num] = true
-			}
-		}
-	}
-
-	// 从 2 开始分配（1 通常是网关/服务器）
-	for i := 2; i <= 254; i++ {
-		if !usedIPs[i] {
-			return fmt.Sprintf("%s.%d/32", prefix, i)
-		}
-	}
-
-	// 地址耗尽，使用随机
-	return fmt.Sprintf("%s.%d/32", prefix, len(server.Clients)+2)
-}
-
-// parseInt 解析整数
-func parseInt(s string) (int, error) {
-	var n int
-	_, err := fmt.Sscanf(s, "%d", &n)
-	return n, err
-}
-
 // DeleteClient 删除客户端
 func (s *Service) DeleteClient(serverID, clientID string) error {
 	s.mu.Lock()
@@ -254,6 +260,8 @@ func (s *Service) UpdateServer(server *WireGuardServer) error {
 			server.PrivateKey = s.config.Servers[i].PrivateKey
 			server.PublicKey = s.config.Servers[i].PublicKey
 			server.Clients = s.config.Servers[i].Clients
+			server.SitePeers = s.config.Servers[i].SitePeers
+			server.PendingRotations = s.config.Servers[i].PendingRotations
 			server.CreatedAt = s.config.Servers[i].CreatedAt
 			s.config.Servers[i] = *server
 			return s.saveConfig()
@@ -0,0 +1,207 @@
+package wireguard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// onlineThreshold 握手在这个时间窗口内的客户端视为在线
+const onlineThreshold = 3 * time.Minute
+
+// statsPollInterval 后台轮询刷新 peer 状态的间隔
+const statsPollInterval = 15 * time.Second
+
+// PeerStats 是从 wg UAPI 的 get=1 响应里解析出来的单个 peer 状态
+type PeerStats struct {
+	PublicKey           string    `json:"publicKey"`
+	Endpoint            string    `json:"endpoint"`
+	LastHandshake       time.Time `json:"lastHandshake"`
+	RxBytes             int64     `json:"rxBytes"`
+	TxBytes             int64     `json:"txBytes"`
+	PersistentKeepalive int       `json:"persistentKeepalive"`
+}
+
+// Stats 返回某个服务器下所有 peer 的实时状态，直接来自 UAPI，不经过 `wg show`
+func (s *Service) Stats(serverID string) ([]PeerStats, error) {
+	server, err := s.GetServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	byPubKey, err := s.backend().Stats(server.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]PeerStats, 0, len(server.Clients))
+	for _, c := range server.Clients {
+		if st, ok := byPubKey[c.PublicKey]; ok {
+			stats = append(stats, st)
+		}
+	}
+	for _, p := range server.SitePeers {
+		if st, ok := byPubKey[p.PublicKey]; ok {
+			stats = append(stats, st)
+		}
+	}
+	for _, rot := range server.PendingRotations {
+		if st, ok := byPubKey[rot.OldPublicKey]; ok {
+			stats = append(stats, st)
+		}
+	}
+	return stats, nil
+}
+
+// ClientStats 返回单个客户端的实时 peer 状态
+func (s *Service) ClientStats(serverID, clientID string) (*PeerStats, error) {
+	server, err := s.GetServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *WireGuardClient
+	for i := range server.Clients {
+		if server.Clients[i].ID == clientID {
+			client = &server.Clients[i]
+			break
+		}
+	}
+	if client == nil {
+		return nil, fmt.Errorf("客户端不存在")
+	}
+
+	byPubKey, err := s.backend().Stats(server.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if st, ok := byPubKey[client.PublicKey]; ok {
+		return &st, nil
+	}
+	return nil, nil
+}
+
+// startStatsPoller 周期性地拉取每个已启用接口的 peer 状态，缓存下来供
+// GetServer/GetServers 合并展示，避免每次查询都去打开 UAPI socket
+func (s *Service) startStatsPoller() {
+	go func() {
+		ticker := time.NewTicker(statsPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.refreshStatsCache()
+		}
+	}()
+}
+
+func (s *Service) refreshStatsCache() {
+	s.mu.RLock()
+	servers := make([]WireGuardServer, len(s.config.Servers))
+	copy(servers, s.config.Servers)
+	s.mu.RUnlock()
+
+	for _, server := range servers {
+		if !server.Enabled {
+			continue
+		}
+		byPubKey, err := s.backend().Stats(server.Tag)
+		if err != nil {
+			continue
+		}
+		s.statsMu.Lock()
+		if s.statsCache == nil {
+			s.statsCache = map[string]map[string]PeerStats{}
+		}
+		s.statsCache[server.ID] = byPubKey
+		s.statsMu.Unlock()
+	}
+}
+
+// cachedStats 返回服务器的最近一次轮询结果（可能为空）
+func (s *Service) cachedStats(serverID string) map[string]PeerStats {
+	s.statsMu.RLock()
+	defer s.statsMu.RUnlock()
+	return s.statsCache[serverID]
+}
+
+// parseUAPIDump 解析 wg UAPI `get=1` 命令的响应，按 peer 的 public_key 分组：
+// 每当遇到新的 public_key 行，就把上一个 peer 的累积字段写回结果集
+func parseUAPIDump(dump string) map[string]PeerStats {
+	result := map[string]PeerStats{}
+	var cur *PeerStats
+
+	flush := func() {
+		if cur != nil {
+			result[cur.PublicKey] = *cur
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(dump))
+	for scanner.Scan() {
+		line := scanner.Text()
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := kv[0], kv[1]
+
+		switch key {
+		case "public_key":
+			flush()
+			cur = &PeerStats{PublicKey: hexToBase64(val)}
+		case "endpoint":
+			if cur != nil {
+				cur.Endpoint = val
+			}
+		case "last_handshake_time_sec":
+			if cur != nil {
+				if sec, err := strconv.ParseInt(val, 10, 64); err == nil && sec > 0 {
+					cur.LastHandshake = time.Unix(sec, 0)
+				}
+			}
+		case "tx_bytes":
+			if cur != nil {
+				if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+					cur.TxBytes = n
+				}
+			}
+		case "rx_bytes":
+			if cur != nil {
+				if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+					cur.RxBytes = n
+				}
+			}
+		case "persistent_keepalive_interval":
+			if cur != nil {
+				if n, err := strconv.Atoi(val); err == nil {
+					cur.PersistentKeepalive = n
+				}
+			}
+		}
+	}
+	flush()
+	return result
+}
+
+// hexToBase64 把 UAPI 返回的十六进制公钥转回 base64，方便和 WireGuardClient.PublicKey 比对
+func hexToBase64(h string) string {
+	raw, err := hex.DecodeString(h)
+	if err != nil {
+		return h
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// dialUAPI 连接内核 wg 接口的 UAPI unix socket
+func dialUAPI(tag string) (net.Conn, error) {
+	return net.Dial("unix", fmt.Sprintf("/var/run/wireguard/%s.sock", tag))
+}
+
+// isOnline 判断某次握手是否还在在线窗口内
+func isOnline(t time.Time) bool {
+	return !t.IsZero() && time.Since(t) < onlineThreshold
+}
@@ -0,0 +1,66 @@
+package wireguard
+
+import "time"
+
+// WireGuardConfig 持久化到 wireguard.json 的顶层配置
+type WireGuardConfig struct {
+	Servers       []WireGuardServer `json:"servers"`
+	Backend       string            `json:"backend,omitempty"`       // "kernel" | "userspace"，默认 "kernel"
+	RotationGrace time.Duration     `json:"rotationGrace,omitempty"` // 密钥轮换的宽限期，默认 24h
+}
+
+// WireGuardServer 一个 WireGuard 接口（服务端）
+type WireGuardServer struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Tag        string            `json:"tag"` // 接口名，如 wg0
+	PrivateKey string            `json:"privateKey"`
+	PublicKey  string            `json:"publicKey"`
+	Address    string            `json:"address"`  // CIDR，如 10.0.0.1/24
+	Endpoint   string            `json:"endpoint"` // 客户端拨入用的公网地址，如 vpn.example.com:51820
+	ListenPort int               `json:"listenPort"`
+	MTU        int               `json:"mtu"`
+	DNS        string            `json:"dns"`
+	PostUp     string            `json:"postUp,omitempty"`
+	PostDown   string            `json:"postDown,omitempty"`
+	Enabled    bool              `json:"enabled"`
+	Clients    []WireGuardClient `json:"clients"`
+	SitePeers  []SitePeer        `json:"sitePeers,omitempty"`
+
+	// PendingRotations 是正在宽限期内的密钥轮换：旧公钥仍作为一个临时 peer 保留，
+	// 直到 ExpiresAt 过期被后台协程清理
+	PendingRotations []PendingRotation `json:"pendingRotations,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PendingRotation 记录一次尚在宽限期内的密钥轮换
+type PendingRotation struct {
+	ID              string    `json:"id"`
+	ClientID        string    `json:"clientId,omitempty"` // 被轮换的客户端；RotateServerKey 不产生记录，渲染时跳过 ClientID 为空的条目
+	OldPublicKey    string    `json:"oldPublicKey"`
+	OldPresharedKey string    `json:"oldPresharedKey,omitempty"`
+	AllowedIPs      string    `json:"allowedIPs"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// WireGuardClient 一个对端客户端
+type WireGuardClient struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Description         string    `json:"description"`
+	PrivateKey          string    `json:"privateKey"`
+	PublicKey           string    `json:"publicKey"`
+	PresharedKey        string    `json:"presharedKey"`
+	AllowedIPs          string    `json:"allowedIPs"`
+	DNS                 string    `json:"dns"`
+	Endpoint            string    `json:"endpoint,omitempty"`
+	PersistentKeepalive int       `json:"persistentKeepalive,omitempty"`
+	Enabled             bool      `json:"enabled"`
+	CreatedAt           time.Time `json:"createdAt"`
+
+	// Online/Stats 是运行时信息，由后台轮询填充，从不持久化到 wireguard.json
+	Online bool       `json:"online,omitempty"`
+	Stats  *PeerStats `json:"stats,omitempty"`
+}
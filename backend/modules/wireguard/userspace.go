@@ -0,0 +1,253 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.zx2c4.com/wireguard/conn"
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/tun"
+)
+
+// userspaceIface 是一个在用户态跑起来的 WireGuard 接口：一张 TUN 设备
+// 加一个 wireguard-go 的 Device，配置通过 UAPI ipc 协议下发，不落地 wg-quick 文件。
+type userspaceIface struct {
+	dev *device.Device
+	tun tun.Device
+}
+
+// userspaceBackendImpl 在进程内管理若干用户态接口，挂在 Service 上按 Backend
+// 字段选中，不依赖内核模块和 wg/wg-quick 工具，可在 Linux/macOS/Windows 及 dev-mode 下运行；
+// 创建 TUN 设备和给接口配地址仍需要系统的网络管理权限。
+type userspaceBackendImpl struct {
+	mu    sync.Mutex
+	ifces map[string]*userspaceIface
+}
+
+func (s *Service) userspaceBackend() ifaceBackend {
+	s.usMu.Lock()
+	defer s.usMu.Unlock()
+	if s.us == nil {
+		s.us = &userspaceBackendImpl{ifces: map[string]*userspaceIface{}}
+	}
+	return s.us
+}
+
+func (u *userspaceBackendImpl) Start(server *WireGuardServer) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.ifces[server.Tag]; ok {
+		return fmt.Errorf("接口 %s 已在运行", server.Tag)
+	}
+
+	tunDev, err := tun.CreateTUN(server.Tag, server.MTU)
+	if err != nil {
+		return fmt.Errorf("创建 TUN 设备失败: %v", err)
+	}
+
+	dev := device.NewDevice(tunDev, conn.NewDefaultBind(), device.NewLogger(device.LogLevelError, server.Tag))
+	if err := dev.IpcSet(renderUAPIConfig(server)); err != nil {
+		dev.Close()
+		return fmt.Errorf("下发接口配置失败: %v", err)
+	}
+	if err := dev.Up(); err != nil {
+		dev.Close()
+		return fmt.Errorf("启动接口失败: %v", err)
+	}
+
+	// wireguard-go 只管加解密和路由表之外的事，接口本身的地址还是要走一次系统
+	// 网络配置命令才能让 TUN 设备真正承载隧道流量——这一步不依赖 wg/wg-quick，
+	// 纯粹是把 server.Address 里的每个 CIDR 装到刚建好的接口上。
+	if err := assignInterfaceAddresses(server.Tag, server.Address); err != nil {
+		dev.Close()
+		return fmt.Errorf("配置接口地址失败: %v", err)
+	}
+
+	u.ifces[server.Tag] = &userspaceIface{dev: dev, tun: tunDev}
+	return nil
+}
+
+// assignInterfaceAddresses 把逗号分隔的 CIDR 列表挨个装到指定接口上
+func assignInterfaceAddresses(tag, addressCSV string) error {
+	for _, cidr := range strings.Split(addressCSV, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if err := assignInterfaceAddress(tag, cidr); err != nil {
+			return fmt.Errorf("%s: %v", cidr, err)
+		}
+	}
+	return nil
+}
+
+// assignInterfaceAddress 给接口装一个地址并拉起链路层，各平台命令不同
+func assignInterfaceAddress(tag, cidr string) error {
+	var cmds [][]string
+	switch runtime.GOOS {
+	case "linux":
+		cmds = [][]string{
+			{"ip", "address", "add", cidr, "dev", tag},
+			{"ip", "link", "set", "up", "dev", tag},
+		}
+	case "darwin":
+		cmds = [][]string{
+			{"ifconfig", tag, "inet", cidr, cidr, "up"},
+		}
+	case "windows":
+		cmds = [][]string{
+			{"netsh", "interface", "ip", "add", "address", tag, cidr},
+		}
+	default:
+		return fmt.Errorf("不支持在 %s 上自动配置接口地址", runtime.GOOS)
+	}
+
+	for _, args := range cmds {
+		if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%v: %s", err, out)
+		}
+	}
+	return nil
+}
+
+func (u *userspaceBackendImpl) Stop(tag string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	iface, ok := u.ifces[tag]
+	if !ok {
+		return fmt.Errorf("接口 %s 未运行", tag)
+	}
+	iface.dev.Close()
+	delete(u.ifces, tag)
+	return nil
+}
+
+func (u *userspaceBackendImpl) Status(tag string) (*InterfaceStatus, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, ok := u.ifces[tag]
+	return &InterfaceStatus{Up: ok}, nil
+}
+
+// Stats 直接向进程内的 Device 要 IpcGet 输出，和内核 UAPI 的 get=1 是同一套文本协议
+func (u *userspaceBackendImpl) Stats(tag string) (map[string]PeerStats, error) {
+	u.mu.Lock()
+	iface, ok := u.ifces[tag]
+	u.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("接口 %s 未运行", tag)
+	}
+
+	dump, err := iface.dev.IpcGet()
+	if err != nil {
+		return nil, fmt.Errorf("读取接口状态失败: %v", err)
+	}
+	return parseUAPIDump(dump), nil
+}
+
+// UpdatePeers 直接对运行中的 Device 下发新的 peer 列表，设备的握手状态不受影响
+func (u *userspaceBackendImpl) UpdatePeers(server *WireGuardServer) error {
+	u.mu.Lock()
+	iface, ok := u.ifces[server.Tag]
+	u.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("接口 %s 未运行", server.Tag)
+	}
+	return iface.dev.IpcSet(renderUAPIPeersOnly(server))
+}
+
+// renderUAPIConfig 把服务器及其客户端渲染成 wireguard-go 的 UAPI ipc 配置，
+// 直接基于 WireGuardServer/WireGuardClient 结构体生成，跳过 wg-quick 文件往返。
+func renderUAPIConfig(server *WireGuardServer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "private_key=%s\n", hexKey(server.PrivateKey))
+	fmt.Fprintf(&b, "listen_port=%d\n", server.ListenPort)
+	fmt.Fprintf(&b, "replace_peers=true\n")
+	writeUAPIPeers(&b, server)
+	return b.String()
+}
+
+// renderUAPIPeersOnly 只渲染 peer 列表（不带 private_key/listen_port），用于密钥
+// 轮换宽限期到期等场景下热更新 peer 集合，不需要把接口下线重建
+func renderUAPIPeersOnly(server *WireGuardServer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "replace_peers=true\n")
+	writeUAPIPeers(&b, server)
+	return b.String()
+}
+
+// writeUAPIPeers 写出仍在宽限期内的旧密钥、客户端以及站点对端的 peer 配置行。
+// UAPI 和 wg 一样按配置顺序给同一个 allowed_ip 分配归属，所以旧密钥的条目必须
+// 写在对应客户端 *之前*，否则新密钥的客户端收不到回程流量。
+func writeUAPIPeers(b *strings.Builder, server *WireGuardServer) {
+	for _, rot := range server.PendingRotations {
+		if rot.ClientID == "" {
+			// 服务器自身换钥不适用 peer 重叠，不应该出现在这里
+			continue
+		}
+		fmt.Fprintf(b, "public_key=%s\n", hexKey(rot.OldPublicKey))
+		if rot.OldPresharedKey != "" {
+			fmt.Fprintf(b, "preshared_key=%s\n", hexKey(rot.OldPresharedKey))
+		}
+		for _, ip := range strings.Split(rot.AllowedIPs, ",") {
+			ip = strings.TrimSpace(ip)
+			if ip != "" {
+				fmt.Fprintf(b, "allowed_ip=%s\n", ip)
+			}
+		}
+	}
+
+	for _, c := range server.Clients {
+		if !c.Enabled {
+			continue
+		}
+		fmt.Fprintf(b, "public_key=%s\n", hexKey(c.PublicKey))
+		if c.PresharedKey != "" {
+			fmt.Fprintf(b, "preshared_key=%s\n", hexKey(c.PresharedKey))
+		}
+		if c.Endpoint != "" {
+			fmt.Fprintf(b, "endpoint=%s\n", c.Endpoint)
+		}
+		if c.PersistentKeepalive > 0 {
+			fmt.Fprintf(b, "persistent_keepalive_interval=%d\n", c.PersistentKeepalive)
+		}
+		for _, ip := range strings.Split(c.AllowedIPs, ",") {
+			ip = strings.TrimSpace(ip)
+			if ip != "" {
+				fmt.Fprintf(b, "allowed_ip=%s\n", ip)
+			}
+		}
+	}
+
+	for _, p := range server.SitePeers {
+		fmt.Fprintf(b, "public_key=%s\n", hexKey(p.PublicKey))
+		if p.PresharedKey != "" {
+			fmt.Fprintf(b, "preshared_key=%s\n", hexKey(p.PresharedKey))
+		}
+		if p.Endpoint != "" {
+			fmt.Fprintf(b, "endpoint=%s\n", p.Endpoint)
+		}
+		if p.PersistentKeepalive > 0 {
+			fmt.Fprintf(b, "persistent_keepalive_interval=%d\n", p.PersistentKeepalive)
+		}
+		for _, subnet := range p.RemoteSubnets {
+			fmt.Fprintf(b, "allowed_ip=%s\n", subnet.String())
+		}
+	}
+}
+
+// hexKey 把 base64 存储的密钥转成 UAPI 需要的十六进制形式
+func hexKey(b64 string) string {
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(raw)
+}